@@ -3,42 +3,37 @@ package crawl
 import (
 	"context"
 	"github.com/rs/zerolog"
-	"sync"
 	"time"
 )
 
-type workRequest struct {
-	url      string
-	complete chan<- []Link
-	error    chan<- error
-}
-
 type workerPool struct {
-	workQueue chan workRequest
+	frontier       chan frontierEntry
+	idle           idleWorkerTracker
+	tracker        urlTracker
+	store          CrawlStore
+	robots         *RobotsPolicy
+	shouldCrawlURL URLEligibilityChecker
 }
 
-func (w *workerPool) startWorkerPool(ctx context.Context, readDocument DocumentReader, readTimeout time.Duration, workerCount int) func() {
-	stopSignals := make([]chan bool, 0)
-	w.workQueue = make(chan workRequest)
+// startWorkerPool spins up workerCount workers pulling off w.frontier. Each
+// worker marks itself busy while it fetches and records a URL (via
+// readDocument, honoring robots.txt pacing when robots is configured), then
+// marks itself idle again while it waits on the frontier for the next one -
+// idleWorkerTracker uses this to detect when the crawl has gone quiet.
+// Crawl store errors encountered by any worker are sent to errs (see
+// process for which failures are only logged). The returned channel
+// stops every worker when closed.
+func (w *workerPool) startWorkerPool(ctx context.Context, readDocument DocumentReader, readTimeout time.Duration, workerCount int, errs chan<- error) chan<- bool {
+	stop := make(chan bool)
 
 	startWorker := func(workerNo int) {
-		stop := make(chan bool)
-		stopSignals = append(stopSignals, stop)
-
 		go func() {
 			for {
+				w.idle.MarkIdle(workerNo)
 				select {
-				case w := <-w.workQueue:
-					logger := zerolog.Ctx(ctx).With().Int("worker", workerNo).Logger()
-					localCtx := logger.WithContext(ctx)
-					localCtx, _ = context.WithTimeout(localCtx, readTimeout)
-
-					res, err := readDocument(localCtx, w.url)
-					if err != nil {
-						w.error <- err
-					} else {
-						w.complete <- res
-					}
+				case entry := <-w.frontier:
+					w.idle.MarkBusy(workerNo)
+					w.process(ctx, readDocument, readTimeout, workerNo, entry, errs)
 				case <-stop:
 					return
 				}
@@ -46,58 +41,58 @@ func (w *workerPool) startWorkerPool(ctx context.Context, readDocument DocumentR
 		}()
 	}
 
-	for i := 0; i <= workerCount; i++ {
+	for i := 0; i < workerCount; i++ {
 		startWorker(i)
 	}
 
-	stop := func() {
-		for _, stop := range stopSignals {
-			stop <- true
-		}
-	}
 	return stop
 }
 
-func (w *workerPool) queueRead(url string) (<-chan []Link, <-chan error) {
-	complete := make(chan []Link)
-	onErr := make(chan error)
-	w.workQueue <- workRequest{url, complete, onErr}
-	return complete, onErr
-}
+// process fetches a single frontier entry, records the result in w.store
+// and, if entry.recurse is set, appends any eligible, not yet seen links to
+// the frontier for workers to pick up in turn. A failure to fetch or parse
+// entry.url itself (a dead link, a timeout, robots pacing being interrupted)
+// only affects that one URL and is logged rather than sent to errs - errs is
+// reserved for failures of the crawl's own persistence layer, which leave it
+// unable to make progress at all.
+func (w *workerPool) process(ctx context.Context, readDocument DocumentReader, readTimeout time.Duration, workerNo int, entry frontierEntry, errs chan<- error) {
+	if w.robots != nil {
+		if !w.robots.Allows(ctx, entry.url) {
+			zerolog.Ctx(ctx).Debug().Str("url", entry.url).Msg("skipping url disallowed by robots.txt")
+			return
+		}
+		if err := w.robots.Await(ctx, entry.url); err != nil {
+			zerolog.Ctx(ctx).Debug().Err(err).Str("url", entry.url).Msg("skipping url, interrupted while waiting on robots.txt pacing")
+			return
+		}
+	}
 
-type siteMap struct {
-	mutex   sync.Mutex
-	siteMap map[string][]Link
-}
+	logger := zerolog.Ctx(ctx).With().Int("worker", workerNo).Logger()
+	localCtx := logger.WithContext(ctx)
+	localCtx, _ = context.WithTimeout(localCtx, readTimeout)
 
-func (s *siteMap) addURL(url string, links []Link) {
-	s.mutex.Lock()
-	if s.siteMap == nil {
-		s.siteMap = make(map[string][]Link)
+	links, err := readDocument(localCtx, entry.url)
+	if err != nil {
+		zerolog.Ctx(ctx).Warn().Err(err).Str("url", entry.url).Msg("skipping url, failed to read")
+		return
 	}
-	defer s.mutex.Unlock()
-	s.siteMap[url] = links
-}
 
-type visitedURLTracker struct {
-	mutex    sync.Mutex
-	urlsSeen map[string]bool
-}
+	if err := w.store.RecordResult(entry.url, links); err != nil {
+		errs <- err
+		return
+	}
 
-func (v *visitedURLTracker) hasURLBeenSeenPreviously(url string) bool {
-	v.mutex.Lock()
-	defer v.mutex.Unlock()
-	_, seen := v.urlsSeen[url]
-	if !seen {
-		v.urlsSeen[url] = true
+	if !entry.recurse {
+		return
 	}
-	return seen
-}
 
-func newURLTracker(startingURL string) visitedURLTracker {
-	return visitedURLTracker{
-		urlsSeen: map[string]bool{
-			startingURL: true,
-		},
+	for _, l := range links {
+		if !w.shouldCrawlURL(l.LinkTarget, l.Tag) {
+			continue
+		}
+		if err := w.tracker.appendURL(l.LinkTarget, l.Tag == LinkScopePrimary); err != nil {
+			errs <- err
+			return
+		}
 	}
 }
@@ -30,34 +30,42 @@ func Test_parseLinks(t *testing.T) {
 				{
 					LinkTypeA,
 					"http://foo.bar.com/icky_whitespace",
+					LinkScopePrimary,
 				},
 				{
 					LinkTypeA,
 					"http://foo.bar.com/nice_link",
+					LinkScopePrimary,
 				},
 				{
 					LinkTypeA,
 					"http://foo.bar.com/CasingFun",
+					LinkScopePrimary,
 				},
 				{
 					LinkTypeA,
 					"http://foo.bar.com/noproto",
+					LinkScopePrimary,
 				},
 				{
 					LinkTypeA,
 					"mailto:someemail@foo.bar.com",
+					LinkScopePrimary,
 				},
 				{
 					LinkTypeA,
 					"http://foo.bar.com/blah.html",
+					LinkScopePrimary,
 				},
 				{
 					LinkTypeA,
 					"http://foo.bar.com/img",
+					LinkScopePrimary,
 				},
 				{
 					LinkTypeImg,
 					"http://foo.bar.com/somimage",
+					LinkScopeResource,
 				},
 			},
 		},
@@ -65,21 +73,25 @@ func Test_parseLinks(t *testing.T) {
 			"invalid html",
 			"testresources/invalid.html",
 			[]Link{
-				Link{
+				{
 					LinkTypeA,
 					"http://foo.bar.com/icky_whitespace",
+					LinkScopePrimary,
 				},
 				{
 					LinkTypeA,
 					"http://foo.bar.com/nice_link",
+					LinkScopePrimary,
 				},
 				{
 					LinkTypeA,
 					"http://foo.bar.com/CasingFun",
+					LinkScopePrimary,
 				},
 				{
 					LinkTypeA,
 					"mailto:someemail@foo.bar.com",
+					LinkScopePrimary,
 				},
 			},
 		},
@@ -134,34 +146,42 @@ func Test_ReadDocument_HappyPath(t *testing.T) {
 		{
 			LinkTypeA,
 			"http://foo.bar.com/icky_whitespace",
+			LinkScopePrimary,
 		},
 		{
 			LinkTypeA,
 			"http://foo.bar.com/nice_link",
+			LinkScopePrimary,
 		},
 		{
 			LinkTypeA,
 			"http://foo.bar.com/CasingFun",
+			LinkScopePrimary,
 		},
 		{
 			LinkTypeA,
 			"http://foo.bar.com/noproto",
+			LinkScopePrimary,
 		},
 		{
 			LinkTypeA,
 			"mailto:someemail@foo.bar.com",
+			LinkScopePrimary,
 		},
 		{
 			LinkTypeA,
 			fmt.Sprintf("%s/blah.html", ts.URL),
+			LinkScopePrimary,
 		},
 		{
 			LinkTypeA,
 			"http://foo.bar.com/img",
+			LinkScopePrimary,
 		},
 		{
 			LinkTypeImg,
 			fmt.Sprintf("%s/somimage", ts.URL),
+			LinkScopeResource,
 		},
 	}, res)
 	asserter.NoError(err)
@@ -180,60 +200,84 @@ func Test_SameDomainEligibilityChecker(t *testing.T) {
 		desc           string
 		baseURL        string
 		input          string
+		scope          LinkScope
 		expectedResult bool
 	}{
 		{
 			"base match",
 			"https://foo.bar.com",
 			"https://foo.bar.com/blah.html",
+			LinkScopePrimary,
 			true,
 		},
 		{
 			"case insensitive match",
 			"https://Foo.bar.com",
 			"https://foo.Bar.com/blah.html",
+			LinkScopePrimary,
 			true,
 		},
 		{
 			"no match",
 			"https://notfoo.bar.com",
 			"https://foo.bar.com/blah.html",
+			LinkScopePrimary,
 			false,
 		},
 		{
 			"different protocol OK",
 			"https://foo.bar.com",
 			"http://foo.bar.com/blah.html",
+			LinkScopePrimary,
 			true,
 		},
 		{
 			"mailto shot down",
 			"https://foo.bar.com",
 			"mailto:bob@foo.bar.com",
+			LinkScopePrimary,
 			false,
 		},
 		{
 			"match on only hostname shot down",
 			"https://foo.bar.com",
 			"https://foo.bob.com",
+			LinkScopePrimary,
 			false,
 		},
 		{
 			"garbage cleanly rejected",
 			"https://foo.bar.com",
 			"this isn't a URL but doesn't trigger an error on url.parse",
+			LinkScopePrimary,
 			false,
 		},
 		{
 			"more garbage cleanly rejected",
 			"https://foo.bar.com",
 			" https://foo.bar.com/blah",
+			LinkScopePrimary,
 			false,
 		},
 		{
 			"non http protocol rejected",
 			"https://foo.bar.com",
 			"madeup://foo.bar.com/blah",
+			LinkScopePrimary,
+			false,
+		},
+		{
+			"resource scope allowed cross host",
+			"https://foo.bar.com",
+			"https://cdn.example.com/logo.png",
+			LinkScopeResource,
+			true,
+		},
+		{
+			"resource scope still rejects mailto",
+			"https://foo.bar.com",
+			"mailto:bob@foo.bar.com",
+			LinkScopeResource,
 			false,
 		},
 	}
@@ -244,7 +288,7 @@ func Test_SameDomainEligibilityChecker(t *testing.T) {
 
 			toTest := SameDomainEligibilityChecker(tc.baseURL)
 
-			asserter.Equal(tc.expectedResult, toTest(tc.input))
+			asserter.Equal(tc.expectedResult, toTest(tc.input, tc.scope))
 		})
 	}
 }
@@ -257,32 +301,32 @@ func Test_NewCrawler_HappyPath(t *testing.T) {
 	// note - need more links than workers to make sure we don't end up blocking when publishing to the work queue
 	linkStructure := map[string][]Link{
 		"start": {
-			{LinkTypeA, "A"},
-			{LinkTypeA, "B"},
-			{LinkTypeA, "D"},
-			{LinkTypeA, "E"},
-			{LinkTypeA, "F"},
-			{LinkTypeA, "G"},
-			{LinkTypeA, "H"},
-			{LinkTypeA, "I"},
-			{LinkTypeA, "J"},
-			{LinkTypeA, "K"},
-			{LinkTypeA, "L"},
-			{LinkTypeImg, "IMG"},
-			{LinkTypeA, "start"},
+			{LinkTypeA, "A", LinkScopePrimary},
+			{LinkTypeA, "B", LinkScopePrimary},
+			{LinkTypeA, "D", LinkScopePrimary},
+			{LinkTypeA, "E", LinkScopePrimary},
+			{LinkTypeA, "F", LinkScopePrimary},
+			{LinkTypeA, "G", LinkScopePrimary},
+			{LinkTypeA, "H", LinkScopePrimary},
+			{LinkTypeA, "I", LinkScopePrimary},
+			{LinkTypeA, "J", LinkScopePrimary},
+			{LinkTypeA, "K", LinkScopePrimary},
+			{LinkTypeA, "L", LinkScopePrimary},
+			{LinkTypeImg, "IMG", LinkScopeResource},
+			{LinkTypeA, "start", LinkScopePrimary},
 		},
 		"A": {
-			{LinkTypeA, "B"},
-			{LinkTypeA, "C"},
-			{LinkTypeA, "Z"},
-			{LinkTypeA, "start"},
+			{LinkTypeA, "B", LinkScopePrimary},
+			{LinkTypeA, "C", LinkScopePrimary},
+			{LinkTypeA, "Z", LinkScopePrimary},
+			{LinkTypeA, "start", LinkScopePrimary},
 		},
 		"B": {
-			{LinkTypeA, "C"},
-			{LinkTypeA, "W"},
+			{LinkTypeA, "C", LinkScopePrimary},
+			{LinkTypeA, "W", LinkScopePrimary},
 		},
 		"C": {
-			{LinkTypeA, "A"},
+			{LinkTypeA, "A", LinkScopePrimary},
 		},
 	}
 
@@ -306,11 +350,11 @@ func Test_NewCrawler_HappyPath(t *testing.T) {
 		return ret, nil
 	}
 
-	shouldIncludeMock := func(s string) bool {
+	shouldIncludeMock := func(s string, _ LinkScope) bool {
 		return s != "W"
 	}
 
-	crawl, _ := NewCrawler(logger, 3, time.Second, reader, shouldIncludeMock)
+	crawl, _ := NewCrawler(logger, 3, time.Second, time.Millisecond*200, reader, shouldIncludeMock, nil, nil)
 
 	timedOut := make(chan bool)
 	go func() {
@@ -345,11 +389,11 @@ func Test_NewCrawler_Shutdown(t *testing.T) {
 		return []Link{}, nil
 	}
 
-	shouldIncludeMock := func(s string) bool {
+	shouldIncludeMock := func(s string, _ LinkScope) bool {
 		return s != "W"
 	}
 
-	crawl, stop := NewCrawler(logger, 10, time.Second*3, reader, shouldIncludeMock)
+	crawl, stop := NewCrawler(logger, 10, time.Second*3, time.Millisecond*200, reader, shouldIncludeMock, nil, nil)
 
 	timedOut := make(chan bool)
 	go func() {
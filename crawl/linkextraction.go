@@ -0,0 +1,239 @@
+package crawl
+
+import (
+	"context"
+	"io"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/rs/zerolog"
+	"golang.org/x/net/html"
+)
+
+// LinkType identifies which HTML (or CSS) construct a Link was extracted
+// from.
+type LinkType int
+
+const (
+	LinkTypeA      LinkType = iota // <a href>
+	LinkTypeImg                    // <img src> or <img srcset>
+	LinkTypeLink                   // <link href>
+	LinkTypeScript                 // <script src>
+	LinkTypeSource                 // <source src> or <source srcset>
+	LinkTypeIframe                 // <iframe src>
+	LinkTypeCSS                    // CSS @import/url(...) in a <style> block or style attribute
+)
+
+func (l LinkType) String() string {
+	switch l {
+	case LinkTypeA:
+		return "a"
+	case LinkTypeImg:
+		return "img"
+	case LinkTypeLink:
+		return "link"
+	case LinkTypeScript:
+		return "script"
+	case LinkTypeSource:
+		return "source"
+	case LinkTypeIframe:
+		return "iframe"
+	case LinkTypeCSS:
+		return "css"
+	default:
+		return "unknown"
+	}
+}
+
+// LinkScope distinguishes a Link that is part of a page's primary
+// navigational graph (and so should itself be crawled) from one that is
+// merely a related sub-resource of the page it was found on - an image,
+// stylesheet, script, etc - which should be fetched and archived but never
+// recursed into.
+type LinkScope int
+
+const (
+	LinkScopePrimary LinkScope = iota
+	LinkScopeResource
+)
+
+func (s LinkScope) String() string {
+	if s == LinkScopePrimary {
+		return "primary"
+	}
+	return "resource"
+}
+
+type Link struct {
+	LinkType   LinkType
+	LinkTarget string
+	Tag        LinkScope
+}
+
+// LinkExtractor pulls zero or more Links out of a single HTML element's
+// attributes. The registry in linkExtractors dispatches to one or more
+// LinkExtractors by tag name.
+type LinkExtractor interface {
+	Extract(ctx context.Context, source *url.URL, attrs map[string]string) []Link
+}
+
+// attrLinkExtractor is a LinkExtractor driven entirely by which attribute
+// holds the target and what LinkType/LinkScope it should be tagged with. It
+// covers every built-in single-URL-attribute case: <a href>, <link href>,
+// <script src>, <img src>, <source src> and <iframe src>.
+type attrLinkExtractor struct {
+	attr      string
+	linkType  LinkType
+	linkScope LinkScope
+}
+
+func (e attrLinkExtractor) Extract(ctx context.Context, source *url.URL, attrs map[string]string) []Link {
+	val, ok := attrs[e.attr]
+	if !ok || val == "" {
+		return nil
+	}
+	target, err := linkTarget(source, val)
+	if err != nil {
+		zerolog.Ctx(ctx).Warn().Err(err).Msg("error parsing link target")
+		return nil
+	}
+	return []Link{{LinkType: e.linkType, LinkTarget: target, Tag: e.linkScope}}
+}
+
+// srcsetLinkExtractor extracts every candidate URL out of an <img>/<source>
+// srcset attribute - a comma separated list of "url width-or-density pixel
+// descriptor" entries, of which only the URL is of interest here.
+type srcsetLinkExtractor struct {
+	linkType LinkType
+}
+
+func (e srcsetLinkExtractor) Extract(ctx context.Context, source *url.URL, attrs map[string]string) []Link {
+	val, ok := attrs["srcset"]
+	if !ok || val == "" {
+		return nil
+	}
+	ret := make([]Link, 0)
+	for _, candidate := range strings.Split(val, ",") {
+		fields := strings.Fields(candidate)
+		if len(fields) == 0 {
+			continue
+		}
+		target, err := linkTarget(source, fields[0])
+		if err != nil {
+			zerolog.Ctx(ctx).Warn().Err(err).Msg("error parsing srcset candidate")
+			continue
+		}
+		ret = append(ret, Link{LinkType: e.linkType, LinkTarget: target, Tag: LinkScopeResource})
+	}
+	return ret
+}
+
+// cssURLPattern matches the URL out of a CSS `@import url(...)` statement or
+// a `<property>: url(...)` declaration. The prefix is non-greedy so that a
+// line with more than one declaration - extremely common in real/minified
+// CSS - yields a match per url(...) instead of the last one swallowing
+// everything before it.
+var cssURLPattern = regexp.MustCompile(`(?:@import|:).*?url\(["']?([^'"\)]+)["']?\)`)
+
+// extractCSSLinks pulls every url(...)/@import target out of a block of raw
+// CSS text, used for both <style> element bodies and inline style attributes.
+func extractCSSLinks(ctx context.Context, source *url.URL, css string) []Link {
+	ret := make([]Link, 0)
+	for _, match := range cssURLPattern.FindAllStringSubmatch(css, -1) {
+		target, err := linkTarget(source, match[1])
+		if err != nil {
+			zerolog.Ctx(ctx).Warn().Err(err).Msg("error parsing css url() target")
+			continue
+		}
+		ret = append(ret, Link{LinkType: LinkTypeCSS, LinkTarget: target, Tag: LinkScopeResource})
+	}
+	return ret
+}
+
+// linkExtractors registers the built-in LinkExtractors by the tag name they
+// apply to. <a> is the only primary, navigational link type - everything
+// else is a related sub-resource that gets fetched/archived but not
+// recursed into (see LinkScope).
+var linkExtractors = map[string][]LinkExtractor{
+	"a":      {attrLinkExtractor{attr: "href", linkType: LinkTypeA, linkScope: LinkScopePrimary}},
+	"link":   {attrLinkExtractor{attr: "href", linkType: LinkTypeLink, linkScope: LinkScopeResource}},
+	"script": {attrLinkExtractor{attr: "src", linkType: LinkTypeScript, linkScope: LinkScopeResource}},
+	"iframe": {attrLinkExtractor{attr: "src", linkType: LinkTypeIframe, linkScope: LinkScopeResource}},
+	"img": {
+		attrLinkExtractor{attr: "src", linkType: LinkTypeImg, linkScope: LinkScopeResource},
+		srcsetLinkExtractor{linkType: LinkTypeImg},
+	},
+	"source": {
+		attrLinkExtractor{attr: "src", linkType: LinkTypeSource, linkScope: LinkScopeResource},
+		srcsetLinkExtractor{linkType: LinkTypeSource},
+	},
+}
+
+// parseLinks looks for html links in an io stream. It tries to continue on any errors as if nothing was wrong until
+// it encounters the end of the io stream (assuming a logger is setup on the context passed in errors will be logged
+// at a warn level though).
+func parseLinks(ctx context.Context, source *url.URL, r io.Reader) []Link {
+	t := html.NewTokenizer(r)
+	ret := make([]Link, 0)
+	processing := true
+	inStyleTag := false
+	for processing {
+		tt := t.Next()
+		switch tt {
+		case html.ErrorToken:
+			err := t.Err()
+			switch err {
+			case io.EOF:
+				processing = false
+			default:
+				zerolog.Ctx(ctx).Warn().Err(err).Msg("error encountered parsing document")
+			}
+		case html.StartTagToken, html.SelfClosingTagToken:
+			tagNameBytes, hasMoreAttrs := t.TagName()
+			tagName := string(tagNameBytes)
+			ret = append(ret, processElement(ctx, source, t, tagName, hasMoreAttrs)...)
+			inStyleTag = tt == html.StartTagToken && tagName == "style"
+		case html.TextToken:
+			if inStyleTag {
+				ret = append(ret, extractCSSLinks(ctx, source, string(t.Text()))...)
+			}
+		case html.EndTagToken:
+			tagNameBytes, _ := t.TagName()
+			if string(tagNameBytes) == "style" {
+				inStyleTag = false
+			}
+		}
+	}
+	return ret
+}
+
+func processElement(ctx context.Context, source *url.URL, t *html.Tokenizer, tagName string, hasMoreAttrs bool) []Link {
+	attrs := make(map[string]string)
+	for hasMoreAttrs {
+		var attr []byte
+		var val []byte
+		attr, val, hasMoreAttrs = t.TagAttr()
+		attrs[string(attr)] = string(val)
+	}
+
+	ret := make([]Link, 0)
+	for _, extractor := range linkExtractors[tagName] {
+		ret = append(ret, extractor.Extract(ctx, source, attrs)...)
+	}
+	if style, ok := attrs["style"]; ok && style != "" {
+		ret = append(ret, extractCSSLinks(ctx, source, style)...)
+	}
+	return ret
+}
+
+func linkTarget(source *url.URL, href string) (string, error) {
+	if strings.HasPrefix(href, "mailto:") || strings.HasPrefix(href, "http://") || strings.HasPrefix(href, "https://") {
+		return href, nil
+	}
+	relative, err := url.Parse(href)
+	if err != nil {
+		return "", nil
+	}
+	return source.ResolveReference(relative).String(), nil
+}
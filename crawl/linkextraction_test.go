@@ -0,0 +1,89 @@
+package crawl
+
+import (
+	"context"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_parseLinks_ExtendedTagTypes(t *testing.T) {
+	testCases := []struct {
+		desc           string
+		input          string
+		expectedResult []Link
+	}{
+		{
+			"link href",
+			`<link rel="stylesheet" href="/style.css">`,
+			[]Link{
+				{LinkTypeLink, "http://foo.bar.com/style.css", LinkScopeResource},
+			},
+		},
+		{
+			"script src",
+			`<script src="/app.js"></script>`,
+			[]Link{
+				{LinkTypeScript, "http://foo.bar.com/app.js", LinkScopeResource},
+			},
+		},
+		{
+			"iframe src",
+			`<iframe src="/embed.html"></iframe>`,
+			[]Link{
+				{LinkTypeIframe, "http://foo.bar.com/embed.html", LinkScopeResource},
+			},
+		},
+		{
+			"source src",
+			`<source src="/video.mp4">`,
+			[]Link{
+				{LinkTypeSource, "http://foo.bar.com/video.mp4", LinkScopeResource},
+			},
+		},
+		{
+			"img srcset",
+			`<img src="/small.png" srcset="/medium.png 1x, /large.png 2x">`,
+			[]Link{
+				{LinkTypeImg, "http://foo.bar.com/small.png", LinkScopeResource},
+				{LinkTypeImg, "http://foo.bar.com/medium.png", LinkScopeResource},
+				{LinkTypeImg, "http://foo.bar.com/large.png", LinkScopeResource},
+			},
+		},
+		{
+			"source srcset",
+			`<source srcset="/a.webp 480w, /b.webp 800w">`,
+			[]Link{
+				{LinkTypeSource, "http://foo.bar.com/a.webp", LinkScopeResource},
+				{LinkTypeSource, "http://foo.bar.com/b.webp", LinkScopeResource},
+			},
+		},
+		{
+			"style attribute url()",
+			`<div style="background: url('/bg.png')"></div>`,
+			[]Link{
+				{LinkTypeCSS, "http://foo.bar.com/bg.png", LinkScopeResource},
+			},
+		},
+		{
+			"style tag with import and multiple declarations on one line",
+			`<style>@import url("/reset.css"); .a{background:url(/a.png)} .b{background:url(/b.png)}</style>`,
+			[]Link{
+				{LinkTypeCSS, "http://foo.bar.com/reset.css", LinkScopeResource},
+				{LinkTypeCSS, "http://foo.bar.com/a.png", LinkScopeResource},
+				{LinkTypeCSS, "http://foo.bar.com/b.png", LinkScopeResource},
+			},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.desc, func(t *testing.T) {
+			asserter := assert.New(t)
+			origin, err := url.Parse("http://foo.bar.com/blah/wee.html")
+			asserter.NoError(err)
+			res := parseLinks(context.Background(), origin, strings.NewReader(tc.input))
+			asserter.Equal(tc.expectedResult, res)
+		})
+	}
+}
@@ -0,0 +1,215 @@
+// Package report renders a completed crawl's results - the map[string][]Link
+// returned by a crawl.Crawler - into formats suitable for downstream tools:
+// JSON for scripting, GraphML for graph visualization tools like Gephi and
+// Cytoscape, and a standard XML sitemap for publishing.
+package report
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"sort"
+	"time"
+
+	"github.com/jonsabados/crawler/crawl"
+	"github.com/pkg/errors"
+)
+
+// Renderer writes crawl results, as returned by a crawl.Crawler, to w in a
+// specific output format.
+type Renderer func(w io.Writer, results map[string][]crawl.Link) error
+
+// Renderers is every built-in Renderer, keyed by the name used to select it
+// via the -format flag.
+var Renderers = map[string]Renderer{
+	"json":    JSON,
+	"graphml": GraphML,
+	"sitemap": Sitemap,
+}
+
+type jsonLink struct {
+	Type   string `json:"type"`
+	Target string `json:"target"`
+	Scope  string `json:"scope"`
+}
+
+// JSON renders results as an indented JSON object mapping each crawled URL
+// to the links found on it.
+func JSON(w io.Writer, results map[string][]crawl.Link) error {
+	out := make(map[string][]jsonLink, len(results))
+	for url, links := range results {
+		converted := make([]jsonLink, len(links))
+		for i, l := range links {
+			converted[i] = jsonLink{
+				Type:   l.LinkType.String(),
+				Target: l.LinkTarget,
+				Scope:  l.Tag.String(),
+			}
+		}
+		out[url] = converted
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return errors.WithStack(enc.Encode(out))
+}
+
+const (
+	graphmlKeyNodeURL   = "url"
+	graphmlKeyEdgeType  = "linkType"
+	graphmlKeyEdgeScope = "scope"
+)
+
+type graphmlDocument struct {
+	XMLName xml.Name     `xml:"graphml"`
+	Xmlns   string       `xml:"xmlns,attr"`
+	Keys    []graphmlKey `xml:"key"`
+	Graph   graphmlGraph `xml:"graph"`
+}
+
+type graphmlKey struct {
+	ID       string `xml:"id,attr"`
+	For      string `xml:"for,attr"`
+	AttrName string `xml:"attr.name,attr"`
+	AttrType string `xml:"attr.type,attr"`
+}
+
+type graphmlGraph struct {
+	ID          string        `xml:"id,attr"`
+	EdgeDefault string        `xml:"edgedefault,attr"`
+	Nodes       []graphmlNode `xml:"node"`
+	Edges       []graphmlEdge `xml:"edge"`
+}
+
+type graphmlNode struct {
+	ID   string        `xml:"id,attr"`
+	Data []graphmlData `xml:"data"`
+}
+
+type graphmlEdge struct {
+	ID     string        `xml:"id,attr"`
+	Source string        `xml:"source,attr"`
+	Target string        `xml:"target,attr"`
+	Data   []graphmlData `xml:"data"`
+}
+
+type graphmlData struct {
+	Key   string `xml:"key,attr"`
+	Value string `xml:",chardata"`
+}
+
+// GraphML renders results as a GraphML graph - one node per crawled or
+// linked-to URL, and one directed edge per link, tagged with the LinkType
+// and LinkScope it was found as - suitable for import into graph
+// visualization tools such as Gephi or Cytoscape. Nodes and edges are
+// emitted in a stable, sorted order so re-rendering unchanged results
+// produces byte-identical output.
+func GraphML(w io.Writer, results map[string][]crawl.Link) error {
+	nodeSet := make(map[string]bool)
+	for source, links := range results {
+		nodeSet[source] = true
+		for _, l := range links {
+			nodeSet[l.LinkTarget] = true
+		}
+	}
+	urls := make([]string, 0, len(nodeSet))
+	for url := range nodeSet {
+		urls = append(urls, url)
+	}
+	sort.Strings(urls)
+
+	doc := graphmlDocument{
+		Xmlns: "http://graphml.graphdrawing.org/xmlns",
+		Keys: []graphmlKey{
+			{ID: graphmlKeyNodeURL, For: "node", AttrName: "url", AttrType: "string"},
+			{ID: graphmlKeyEdgeType, For: "edge", AttrName: "linkType", AttrType: "string"},
+			{ID: graphmlKeyEdgeScope, For: "edge", AttrName: "scope", AttrType: "string"},
+		},
+		Graph: graphmlGraph{
+			ID:          "crawl",
+			EdgeDefault: "directed",
+		},
+	}
+
+	nodeIDs := make(map[string]string, len(urls))
+	for i, url := range urls {
+		id := fmt.Sprintf("n%d", i)
+		nodeIDs[url] = id
+		doc.Graph.Nodes = append(doc.Graph.Nodes, graphmlNode{
+			ID:   id,
+			Data: []graphmlData{{Key: graphmlKeyNodeURL, Value: url}},
+		})
+	}
+
+	sources := make([]string, 0, len(results))
+	for source := range results {
+		sources = append(sources, source)
+	}
+	sort.Strings(sources)
+
+	edgeNo := 0
+	for _, source := range sources {
+		for _, l := range results[source] {
+			doc.Graph.Edges = append(doc.Graph.Edges, graphmlEdge{
+				ID:     fmt.Sprintf("e%d", edgeNo),
+				Source: nodeIDs[source],
+				Target: nodeIDs[l.LinkTarget],
+				Data: []graphmlData{
+					{Key: graphmlKeyEdgeType, Value: l.LinkType.String()},
+					{Key: graphmlKeyEdgeScope, Value: l.Tag.String()},
+				},
+			})
+			edgeNo++
+		}
+	}
+
+	return encodeXML(w, doc)
+}
+
+type sitemapURLSet struct {
+	XMLName xml.Name     `xml:"urlset"`
+	Xmlns   string       `xml:"xmlns,attr"`
+	URLs    []sitemapURL `xml:"url"`
+}
+
+type sitemapURL struct {
+	Loc     string `xml:"loc"`
+	LastMod string `xml:"lastmod"`
+}
+
+// Sitemap renders the crawled URLs - the keys of results, i.e. pages that
+// were actually fetched, not every linked-to sub-resource - as a standard
+// sitemap.xml 0.9 document, with lastmod set to the time of rendering.
+func Sitemap(w io.Writer, results map[string][]crawl.Link) error {
+	lastmod := time.Now().UTC().Format(time.RFC3339)
+
+	urls := make([]string, 0, len(results))
+	for url := range results {
+		urls = append(urls, url)
+	}
+	sort.Strings(urls)
+
+	doc := sitemapURLSet{
+		Xmlns: "http://www.sitemaps.org/schemas/sitemap/0.9",
+		URLs:  make([]sitemapURL, len(urls)),
+	}
+	for i, url := range urls {
+		doc.URLs[i] = sitemapURL{Loc: url, LastMod: lastmod}
+	}
+
+	return encodeXML(w, doc)
+}
+
+func encodeXML(w io.Writer, doc interface{}) error {
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return errors.WithStack(err)
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(doc); err != nil {
+		return errors.WithStack(err)
+	}
+	_, err := io.WriteString(w, "\n")
+	return errors.WithStack(err)
+}
@@ -0,0 +1,65 @@
+package report
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"testing"
+
+	"github.com/jonsabados/crawler/crawl"
+	"github.com/stretchr/testify/assert"
+)
+
+func testResults() map[string][]crawl.Link {
+	return map[string][]crawl.Link{
+		"http://foo.bar.com/": {
+			{LinkType: crawl.LinkTypeA, LinkTarget: "http://foo.bar.com/blah.html", Tag: crawl.LinkScopePrimary},
+			{LinkType: crawl.LinkTypeImg, LinkTarget: "http://foo.bar.com/logo.png", Tag: crawl.LinkScopeResource},
+		},
+	}
+}
+
+func Test_JSON(t *testing.T) {
+	asserter := assert.New(t)
+
+	var buf bytes.Buffer
+	asserter.NoError(JSON(&buf, testResults()))
+
+	var decoded map[string][]jsonLink
+	asserter.NoError(json.Unmarshal(buf.Bytes(), &decoded))
+	asserter.Equal([]jsonLink{
+		{Type: "a", Target: "http://foo.bar.com/blah.html", Scope: "primary"},
+		{Type: "img", Target: "http://foo.bar.com/logo.png", Scope: "resource"},
+	}, decoded["http://foo.bar.com/"])
+}
+
+func Test_GraphML(t *testing.T) {
+	asserter := assert.New(t)
+
+	var buf bytes.Buffer
+	asserter.NoError(GraphML(&buf, testResults()))
+
+	var decoded graphmlDocument
+	asserter.NoError(xml.Unmarshal(buf.Bytes(), &decoded))
+	asserter.Len(decoded.Graph.Nodes, 3)
+	if asserter.Len(decoded.Graph.Edges, 2) {
+		asserter.Equal("a", decoded.Graph.Edges[0].Data[0].Value)
+		asserter.Equal("primary", decoded.Graph.Edges[0].Data[1].Value)
+		asserter.Equal("img", decoded.Graph.Edges[1].Data[0].Value)
+		asserter.Equal("resource", decoded.Graph.Edges[1].Data[1].Value)
+	}
+}
+
+func Test_Sitemap(t *testing.T) {
+	asserter := assert.New(t)
+
+	var buf bytes.Buffer
+	asserter.NoError(Sitemap(&buf, testResults()))
+
+	var decoded sitemapURLSet
+	asserter.NoError(xml.Unmarshal(buf.Bytes(), &decoded))
+	if asserter.Len(decoded.URLs, 1) {
+		asserter.Equal("http://foo.bar.com/", decoded.URLs[0].Loc)
+		asserter.NotEmpty(decoded.URLs[0].LastMod)
+	}
+}
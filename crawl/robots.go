@@ -0,0 +1,310 @@
+package crawl
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+// RobotsPolicy fetches and caches robots.txt rules on a per scheme+host basis
+// and enforces per-host pacing, honoring a site's Crawl-delay directive when
+// it specifies one and falling back to defaultDelay otherwise. A single
+// RobotsPolicy is safe for concurrent use and is intended to be shared by all
+// workers in a crawl.
+type RobotsPolicy struct {
+	userAgent    string
+	defaultDelay time.Duration
+	httpClient   *http.Client
+
+	mutex    sync.Mutex
+	rules    map[string]*robotsRules
+	limiters map[string]*hostLimiter
+}
+
+// NewRobotsPolicy returns a RobotsPolicy that identifies itself to remote
+// servers as userAgent, and that paces requests to hosts with no Crawl-delay
+// of their own at defaultDelay.
+func NewRobotsPolicy(userAgent string, defaultDelay time.Duration) *RobotsPolicy {
+	return &RobotsPolicy{
+		userAgent:    userAgent,
+		defaultDelay: defaultDelay,
+		httpClient:   http.DefaultClient,
+		rules:        make(map[string]*robotsRules),
+		limiters:     make(map[string]*hostLimiter),
+	}
+}
+
+// Allows reports whether targetURL may be fetched per the target host's
+// robots.txt, fetching and caching that host's rules if this is the first
+// time it has been seen.
+func (p *RobotsPolicy) Allows(ctx context.Context, targetURL string) bool {
+	u, err := url.Parse(targetURL)
+	if err != nil {
+		return false
+	}
+	path := u.Path
+	if path == "" {
+		path = "/"
+	}
+	return p.rulesFor(ctx, u).permits(path)
+}
+
+// Await blocks until targetURL's host is next eligible to be fetched under
+// this policy's per-host pacing, or until ctx is done.
+func (p *RobotsPolicy) Await(ctx context.Context, targetURL string) error {
+	u, err := url.Parse(targetURL)
+	if err != nil {
+		return nil
+	}
+
+	rules := p.rulesFor(ctx, u)
+	delay := p.defaultDelay
+	if rules.delay > 0 {
+		delay = rules.delay
+	}
+
+	key := hostKey(u)
+	p.mutex.Lock()
+	limiter, ok := p.limiters[key]
+	if !ok {
+		limiter = &hostLimiter{delay: delay}
+		p.limiters[key] = limiter
+	}
+	p.mutex.Unlock()
+
+	return limiter.await(ctx)
+}
+
+func (p *RobotsPolicy) rulesFor(ctx context.Context, u *url.URL) *robotsRules {
+	key := hostKey(u)
+
+	p.mutex.Lock()
+	cached, ok := p.rules[key]
+	p.mutex.Unlock()
+	if ok {
+		return cached
+	}
+
+	rules := p.fetchRules(ctx, u)
+
+	p.mutex.Lock()
+	p.rules[key] = rules
+	p.mutex.Unlock()
+
+	return rules
+}
+
+func (p *RobotsPolicy) fetchRules(ctx context.Context, u *url.URL) *robotsRules {
+	robotsURL := fmt.Sprintf("%s://%s/robots.txt", u.Scheme, u.Host)
+
+	req, err := http.NewRequest(http.MethodGet, robotsURL, nil)
+	if err != nil {
+		return &robotsRules{}
+	}
+	req = req.WithContext(ctx)
+	req.Header.Set("User-Agent", p.userAgent)
+
+	res, err := p.httpClient.Do(req)
+	if err != nil {
+		zerolog.Ctx(ctx).Debug().Err(err).Str("url", robotsURL).Msg("unable to fetch robots.txt, assuming crawl is permitted")
+		return &robotsRules{}
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return &robotsRules{}
+	}
+
+	return parseRobotsRules(p.userAgent, res.Body)
+}
+
+func hostKey(u *url.URL) string {
+	return strings.ToLower(u.Scheme) + "://" + strings.ToLower(u.Host)
+}
+
+// robotsRules is the parsed set of directives that apply to a single host for
+// a single user-agent.
+type robotsRules struct {
+	allow    []string
+	disallow []string
+	delay    time.Duration
+}
+
+// permits implements the de-facto robots.txt matching rule: the longest
+// matching path prefix wins, with allow winning ties.
+func (r *robotsRules) permits(path string) bool {
+	allowMatch := -1
+	for _, p := range r.allow {
+		if strings.HasPrefix(path, p) && len(p) > allowMatch {
+			allowMatch = len(p)
+		}
+	}
+	disallowMatch := -1
+	for _, p := range r.disallow {
+		if strings.HasPrefix(path, p) && len(p) > disallowMatch {
+			disallowMatch = len(p)
+		}
+	}
+	return disallowMatch <= allowMatch
+}
+
+// robotsDirective is a single non-user-agent directive (Disallow, Allow or
+// Crawl-delay) belonging to a robotsGroup.
+type robotsDirective struct {
+	field string
+	value string
+}
+
+// robotsGroup is one robots.txt group: the one or more consecutive
+// User-agent lines introducing it, and the directives that follow until the
+// next group starts.
+type robotsGroup struct {
+	agents     []string
+	directives []robotsDirective
+}
+
+// parseRobotsRules extracts the directives that apply to userAgent from a
+// robots.txt document. A document is first split into its groups; the
+// directives of every group whose User-agent list matches userAgent
+// specifically are then applied, wholesale, in place of - not merged with -
+// any other group. Only if no group matches userAgent specifically does the
+// wildcard "*" group, if any, apply.
+func parseRobotsRules(userAgent string, r io.Reader) *robotsRules {
+	groups := parseRobotsGroups(r)
+
+	rules := &robotsRules{}
+	matchedSpecific := false
+	for _, g := range groups {
+		if !groupMatches(g, userAgent) {
+			continue
+		}
+		applyRobotsGroup(rules, g)
+		matchedSpecific = true
+	}
+	if matchedSpecific {
+		return rules
+	}
+
+	for _, g := range groups {
+		if groupMatches(g, "*") {
+			applyRobotsGroup(rules, g)
+		}
+	}
+	return rules
+}
+
+// parseRobotsGroups splits a robots.txt document into its groups. A new
+// group starts at a User-agent line that follows a non-User-agent
+// directive; consecutive User-agent lines all belong to the same group.
+func parseRobotsGroups(r io.Reader) []robotsGroup {
+	var groups []robotsGroup
+	var current robotsGroup
+	lastWasAgent := false
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		field, value, ok := parseDirective(scanner.Text())
+		if !ok {
+			continue
+		}
+		if strings.EqualFold(field, "user-agent") {
+			if !lastWasAgent && len(current.agents) > 0 {
+				groups = append(groups, current)
+				current = robotsGroup{}
+			}
+			current.agents = append(current.agents, value)
+			lastWasAgent = true
+		} else {
+			current.directives = append(current.directives, robotsDirective{field, value})
+			lastWasAgent = false
+		}
+	}
+	if len(current.agents) > 0 {
+		groups = append(groups, current)
+	}
+	return groups
+}
+
+func groupMatches(g robotsGroup, userAgent string) bool {
+	for _, a := range g.agents {
+		if strings.EqualFold(a, userAgent) {
+			return true
+		}
+	}
+	return false
+}
+
+func applyRobotsGroup(rules *robotsRules, g robotsGroup) {
+	for _, d := range g.directives {
+		switch strings.ToLower(d.field) {
+		case "disallow":
+			if d.value != "" {
+				rules.disallow = append(rules.disallow, d.value)
+			}
+		case "allow":
+			if d.value != "" {
+				rules.allow = append(rules.allow, d.value)
+			}
+		case "crawl-delay":
+			if seconds, err := strconv.ParseFloat(d.value, 64); err == nil {
+				rules.delay = time.Duration(seconds * float64(time.Second))
+			}
+		}
+	}
+}
+
+func parseDirective(line string) (field, value string, ok bool) {
+	if idx := strings.Index(line, "#"); idx >= 0 {
+		line = line[:idx]
+	}
+	line = strings.TrimSpace(line)
+	if line == "" {
+		return "", "", false
+	}
+	parts := strings.SplitN(line, ":", 2)
+	if len(parts) != 2 {
+		return "", "", false
+	}
+	return strings.TrimSpace(parts[0]), strings.TrimSpace(parts[1]), true
+}
+
+// hostLimiter is a single-token bucket refilled at a fixed delay - exactly
+// what a Crawl-delay directive is asking for.
+type hostLimiter struct {
+	mutex sync.Mutex
+	delay time.Duration
+	next  time.Time
+}
+
+func (h *hostLimiter) await(ctx context.Context) error {
+	h.mutex.Lock()
+	now := time.Now()
+	wait := h.next.Sub(now)
+	if wait < 0 {
+		wait = 0
+	}
+	h.next = now.Add(wait + h.delay)
+	h.mutex.Unlock()
+
+	if wait == 0 {
+		return nil
+	}
+
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,104 @@
+package crawl
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_parseRobotsRules(t *testing.T) {
+	testCases := []struct {
+		desc          string
+		userAgent     string
+		body          string
+		testPath      string
+		expectedAllow bool
+		expectedDelay time.Duration
+	}{
+		{
+			"wildcard disallow blocks everything",
+			"crawler",
+			"User-agent: *\nDisallow: /\n",
+			"/anything",
+			false,
+			0,
+		},
+		{
+			"specific group overrides wildcard",
+			"crawler",
+			"User-agent: *\nDisallow: /\n\nUser-agent: crawler\nDisallow:\n",
+			"/anything",
+			true,
+			0,
+		},
+		{
+			"allow wins on longer match",
+			"crawler",
+			"User-agent: *\nDisallow: /private\nAllow: /private/public\n",
+			"/private/public/page.html",
+			true,
+			0,
+		},
+		{
+			"crawl-delay is parsed",
+			"crawler",
+			"User-agent: *\nCrawl-delay: 2\n",
+			"/",
+			true,
+			time.Second * 2,
+		},
+		{
+			"unmatched user-agent group is ignored",
+			"crawler",
+			"User-agent: somebotthatisnotus\nDisallow: /\n",
+			"/",
+			true,
+			0,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.desc, func(t *testing.T) {
+			asserter := assert.New(t)
+
+			rules := parseRobotsRules(tc.userAgent, strings.NewReader(tc.body))
+			asserter.Equal(tc.expectedAllow, rules.permits(tc.testPath))
+			asserter.Equal(tc.expectedDelay, rules.delay)
+		})
+	}
+}
+
+func Test_RobotsPolicy_Allows(t *testing.T) {
+	asserter := assert.New(t)
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("User-agent: *\nDisallow: /off-limits\n"))
+	}))
+	defer ts.Close()
+
+	policy := NewRobotsPolicy("crawler", 0)
+
+	asserter.True(policy.Allows(context.Background(), ts.URL+"/fine"))
+	asserter.False(policy.Allows(context.Background(), ts.URL+"/off-limits/page.html"))
+}
+
+func Test_RobotsPolicy_Await_PacesRequestsToTheSameHost(t *testing.T) {
+	asserter := assert.New(t)
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer ts.Close()
+
+	policy := NewRobotsPolicy("crawler", time.Millisecond*50)
+
+	start := time.Now()
+	asserter.NoError(policy.Await(context.Background(), ts.URL+"/a"))
+	asserter.NoError(policy.Await(context.Background(), ts.URL+"/b"))
+	asserter.True(time.Since(start) >= time.Millisecond*50)
+}
@@ -0,0 +1,98 @@
+package crawl
+
+import "sync"
+
+// CrawlStore persists the state of an in-progress crawl - which URLs have
+// been seen, which of those are still pending, and the links discovered for
+// URLs that have already been fetched - so that a crawl interrupted by
+// process death can be resumed rather than started over. The default,
+// in-memory implementation returned by NewMemoryCrawlStore keeps state for
+// the lifetime of a single process; see crawl/store for implementations that
+// persist to disk.
+type CrawlStore interface {
+	// MarkSeen records that url has been added to the frontier with the
+	// given recurse bit (see PendingURL), returning true if it was already
+	// recorded by an earlier call - whether from this run or, for a
+	// persistent implementation, a prior one.
+	MarkSeen(url string, recurse bool) (alreadySeen bool, err error)
+
+	// RecordResult stores the links discovered for url and removes it from
+	// the pending frontier.
+	RecordResult(url string, links []Link) error
+
+	// PendingFrontier returns URLs that were marked seen but never reached
+	// RecordResult, along with the recurse bit each was marked seen with,
+	// so a resumed crawl can pick up where a prior run left off - including
+	// whether a pending URL was a primary link or a resource - instead of
+	// requiring only a fresh starting URL.
+	PendingFrontier() ([]PendingURL, error)
+
+	// Results returns every url to links mapping recorded so far.
+	Results() (map[string][]Link, error)
+}
+
+// PendingURL is a single entry in a CrawlStore's pending frontier: a URL
+// that was marked seen but never reached RecordResult, and the recurse bit
+// it was marked seen with - mirroring frontierEntry so a resumed crawl
+// recurses into exactly the URLs a non-interrupted run would have.
+type PendingURL struct {
+	URL     string
+	Recurse bool
+}
+
+// memoryCrawlStore is the default CrawlStore - it keeps all state in memory
+// and is discarded, along with any in-progress crawl, when the process exits.
+type memoryCrawlStore struct {
+	mutex   sync.Mutex
+	seen    map[string]bool
+	pending map[string]bool
+	results map[string][]Link
+}
+
+// NewMemoryCrawlStore returns a CrawlStore that keeps all state in memory.
+func NewMemoryCrawlStore() CrawlStore {
+	return &memoryCrawlStore{
+		seen:    make(map[string]bool),
+		pending: make(map[string]bool),
+		results: make(map[string][]Link),
+	}
+}
+
+func (m *memoryCrawlStore) MarkSeen(url string, recurse bool) (bool, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	if m.seen[url] {
+		return true, nil
+	}
+	m.seen[url] = true
+	m.pending[url] = recurse
+	return false, nil
+}
+
+func (m *memoryCrawlStore) RecordResult(url string, links []Link) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	m.results[url] = links
+	delete(m.pending, url)
+	return nil
+}
+
+func (m *memoryCrawlStore) PendingFrontier() ([]PendingURL, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	ret := make([]PendingURL, 0, len(m.pending))
+	for url, recurse := range m.pending {
+		ret = append(ret, PendingURL{URL: url, Recurse: recurse})
+	}
+	return ret, nil
+}
+
+func (m *memoryCrawlStore) Results() (map[string][]Link, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	ret := make(map[string][]Link, len(m.results))
+	for url, links := range m.results {
+		ret[url] = links
+	}
+	return ret, nil
+}
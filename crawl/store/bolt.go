@@ -0,0 +1,124 @@
+// Package store provides crawl.CrawlStore implementations that persist crawl
+// state to disk, so a long-running archival crawl can survive an interrupted
+// process without losing hours of work.
+package store
+
+import (
+	"encoding/json"
+	"strconv"
+
+	"github.com/jonsabados/crawler/crawl"
+	"github.com/pkg/errors"
+	bolt "go.etcd.io/bbolt"
+)
+
+var (
+	bucketSeen    = []byte("seen")
+	bucketPending = []byte("pending")
+	bucketResults = []byte("results")
+)
+
+// BoltCrawlStore is a crawl.CrawlStore backed by a BoltDB file, so seen URLs,
+// the pending frontier, and per-URL results all survive a process restart.
+type BoltCrawlStore struct {
+	db *bolt.DB
+}
+
+// NewBoltCrawlStore opens (creating if necessary) a BoltDB database at path
+// for use as crawl state. If path already contains state from a previous
+// crawl, that state is preserved and will be picked up by NewCrawler.
+func NewBoltCrawlStore(path string) (*BoltCrawlStore, error) {
+	db, err := bolt.Open(path, 0600, nil)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	err = db.Update(func(tx *bolt.Tx) error {
+		for _, bucket := range [][]byte{bucketSeen, bucketPending, bucketResults} {
+			if _, err := tx.CreateBucketIfNotExists(bucket); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		db.Close()
+		return nil, errors.WithStack(err)
+	}
+
+	return &BoltCrawlStore{db: db}, nil
+}
+
+func (s *BoltCrawlStore) MarkSeen(url string, recurse bool) (bool, error) {
+	alreadySeen := false
+	err := s.db.Update(func(tx *bolt.Tx) error {
+		seen := tx.Bucket(bucketSeen)
+		if seen.Get([]byte(url)) != nil {
+			alreadySeen = true
+			return nil
+		}
+		if err := seen.Put([]byte(url), []byte{1}); err != nil {
+			return err
+		}
+		return tx.Bucket(bucketPending).Put([]byte(url), []byte(strconv.FormatBool(recurse)))
+	})
+	if err != nil {
+		return false, errors.WithStack(err)
+	}
+	return alreadySeen, nil
+}
+
+func (s *BoltCrawlStore) RecordResult(url string, links []crawl.Link) error {
+	encoded, err := json.Marshal(links)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	return errors.WithStack(s.db.Update(func(tx *bolt.Tx) error {
+		if err := tx.Bucket(bucketResults).Put([]byte(url), encoded); err != nil {
+			return err
+		}
+		return tx.Bucket(bucketPending).Delete([]byte(url))
+	}))
+}
+
+func (s *BoltCrawlStore) PendingFrontier() ([]crawl.PendingURL, error) {
+	var ret []crawl.PendingURL
+	err := s.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(bucketPending).ForEach(func(k, v []byte) error {
+			recurse, err := strconv.ParseBool(string(v))
+			if err != nil {
+				return err
+			}
+			ret = append(ret, crawl.PendingURL{URL: string(k), Recurse: recurse})
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return ret, nil
+}
+
+func (s *BoltCrawlStore) Results() (map[string][]crawl.Link, error) {
+	ret := make(map[string][]crawl.Link)
+	err := s.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(bucketResults).ForEach(func(k, v []byte) error {
+			var links []crawl.Link
+			if err := json.Unmarshal(v, &links); err != nil {
+				return err
+			}
+			ret[string(k)] = links
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return ret, nil
+}
+
+// Close closes the underlying BoltDB file.
+func (s *BoltCrawlStore) Close() error {
+	return errors.WithStack(s.db.Close())
+}
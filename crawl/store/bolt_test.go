@@ -0,0 +1,58 @@
+package store
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/jonsabados/crawler/crawl"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_BoltCrawlStore_PersistsAcrossReopen(t *testing.T) {
+	asserter := assert.New(t)
+
+	dir, err := ioutil.TempDir("", "bolt_store_test")
+	asserter.NoError(err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "crawl.db")
+
+	first, err := NewBoltCrawlStore(path)
+	asserter.NoError(err)
+
+	alreadySeen, err := first.MarkSeen("start", true)
+	asserter.NoError(err)
+	asserter.False(alreadySeen)
+
+	alreadySeen, err = first.MarkSeen("pending", true)
+	asserter.NoError(err)
+	asserter.False(alreadySeen)
+
+	alreadySeen, err = first.MarkSeen("pending-resource", false)
+	asserter.NoError(err)
+	asserter.False(alreadySeen)
+
+	asserter.NoError(first.RecordResult("start", []crawl.Link{{LinkType: crawl.LinkTypeA, LinkTarget: "pending", Tag: crawl.LinkScopePrimary}}))
+	asserter.NoError(first.Close())
+
+	second, err := NewBoltCrawlStore(path)
+	asserter.NoError(err)
+	defer second.Close()
+
+	alreadySeen, err = second.MarkSeen("start", true)
+	asserter.NoError(err)
+	asserter.True(alreadySeen)
+
+	pending, err := second.PendingFrontier()
+	asserter.NoError(err)
+	asserter.ElementsMatch([]crawl.PendingURL{
+		{URL: "pending", Recurse: true},
+		{URL: "pending-resource", Recurse: false},
+	}, pending)
+
+	results, err := second.Results()
+	asserter.NoError(err)
+	asserter.Equal(map[string][]crawl.Link{"start": {{LinkType: crawl.LinkTypeA, LinkTarget: "pending", Tag: crawl.LinkScopePrimary}}}, results)
+}
@@ -0,0 +1,35 @@
+package crawl
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_memoryCrawlStore(t *testing.T) {
+	asserter := assert.New(t)
+
+	store := NewMemoryCrawlStore()
+
+	alreadySeen, err := store.MarkSeen("start", true)
+	asserter.NoError(err)
+	asserter.False(alreadySeen)
+
+	alreadySeen, err = store.MarkSeen("start", true)
+	asserter.NoError(err)
+	asserter.True(alreadySeen)
+
+	pending, err := store.PendingFrontier()
+	asserter.NoError(err)
+	asserter.Equal([]PendingURL{{URL: "start", Recurse: true}}, pending)
+
+	asserter.NoError(store.RecordResult("start", []Link{{LinkTypeA, "next", LinkScopePrimary}}))
+
+	pending, err = store.PendingFrontier()
+	asserter.NoError(err)
+	asserter.Empty(pending)
+
+	results, err := store.Results()
+	asserter.NoError(err)
+	asserter.Equal(map[string][]Link{"start": {{LinkTypeA, "next", LinkScopePrimary}}}, results)
+}
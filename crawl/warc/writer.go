@@ -0,0 +1,196 @@
+// Package warc writes crawl results out as WARC 1.1 records, suitable for
+// replay in standard tools such as the Wayback Machine's OpenWayback or
+// webrecorder's pywb.
+package warc
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/sha1"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// RecordType is one of the WARC 1.1 record types this package knows how to
+// write.
+type RecordType string
+
+const (
+	RecordTypeWarcinfo RecordType = "warcinfo"
+	RecordTypeRequest  RecordType = "request"
+	RecordTypeResponse RecordType = "response"
+)
+
+// Record is a single WARC record to be appended to a Writer. ID is optional -
+// if left blank a deterministic WARC-Record-ID is generated from Type,
+// TargetURI and Date, so records with the same values for those three
+// always get the same ID.
+type Record struct {
+	Type         RecordType
+	ID           string
+	Date         time.Time
+	TargetURI    string
+	ContentType  string
+	ConcurrentTo string
+	Body         []byte
+}
+
+// Writer appends Records to a rotating sequence of gzip'd WARC files. Each
+// record is written as its own gzip member, per the WARC convention, so a
+// file can be decoded (or truncated) one record at a time. Writer is safe
+// for concurrent use.
+type Writer struct {
+	mutex    sync.Mutex
+	basePath string
+	maxBytes int64
+	sequence int
+	file     *os.File
+	written  int64
+}
+
+// NewWriter returns a Writer that writes to basePath, rotating to a new,
+// sequentially numbered file once the current one reaches maxBytes. A
+// maxBytes of 0 disables rotation. basePath, and each rotated file, is
+// created afresh: any existing file at that path is truncated.
+func NewWriter(basePath string, maxBytes int64) (*Writer, error) {
+	w := &Writer{
+		basePath: basePath,
+		maxBytes: maxBytes,
+	}
+	if err := w.openSequence(0); err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+// WriteRecord encodes r as a WARC 1.1 record and appends it as a new gzip
+// member in the current file, rotating first if the current file has
+// reached its configured size limit. It returns the record's WARC-Record-ID
+// so related records (e.g. a response's request) can reference it via
+// WARC-Concurrent-To.
+func (w *Writer) WriteRecord(r Record) (string, error) {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	if r.ID == "" {
+		r.ID = deterministicRecordID(r.Type, r.TargetURI, r.Date)
+	}
+
+	if w.maxBytes > 0 && w.written >= w.maxBytes {
+		if err := w.openSequence(w.sequence + 1); err != nil {
+			return "", err
+		}
+	}
+
+	counter := &countingWriter{w: w.file}
+	gz := gzip.NewWriter(counter)
+	if _, err := gz.Write(encodeRecord(r)); err != nil {
+		return "", errors.WithStack(err)
+	}
+	if err := gz.Close(); err != nil {
+		return "", errors.WithStack(err)
+	}
+	w.written += counter.n
+
+	return r.ID, nil
+}
+
+// Close closes the file currently being written to.
+func (w *Writer) Close() error {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	if w.file == nil {
+		return nil
+	}
+	return errors.WithStack(w.file.Close())
+}
+
+func (w *Writer) openSequence(seq int) error {
+	if w.file != nil {
+		if err := w.file.Close(); err != nil {
+			return errors.WithStack(err)
+		}
+	}
+	f, err := os.Create(sequencedPath(w.basePath, seq))
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	w.sequence = seq
+	w.file = f
+	w.written = 0
+	return nil
+}
+
+// sequencedPath returns basePath unchanged for seq 0, and otherwise inserts a
+// zero padded sequence number before the file's extension, e.g.
+// "crawl.warc.gz" at seq 1 becomes "crawl-00001.warc.gz".
+func sequencedPath(basePath string, seq int) string {
+	if seq == 0 {
+		return basePath
+	}
+	dir, file := filepath.Split(basePath)
+	ext := ""
+	if idx := strings.Index(file, "."); idx >= 0 {
+		ext = file[idx:]
+		file = file[:idx]
+	}
+	return filepath.Join(dir, fmt.Sprintf("%s-%05d%s", file, seq, ext))
+}
+
+func encodeRecord(r Record) []byte {
+	var buf bytes.Buffer
+	buf.WriteString("WARC/1.1\r\n")
+	writeHeader(&buf, "WARC-Type", string(r.Type))
+	writeHeader(&buf, "WARC-Record-ID", fmt.Sprintf("<%s>", r.ID))
+	writeHeader(&buf, "WARC-Date", r.Date.UTC().Format(time.RFC3339Nano))
+	if r.TargetURI != "" {
+		writeHeader(&buf, "WARC-Target-URI", r.TargetURI)
+	}
+	if r.ConcurrentTo != "" {
+		writeHeader(&buf, "WARC-Concurrent-To", fmt.Sprintf("<%s>", r.ConcurrentTo))
+	}
+	writeHeader(&buf, "Content-Type", r.ContentType)
+	writeHeader(&buf, "Content-Length", strconv.Itoa(len(r.Body)))
+	buf.WriteString("\r\n")
+	buf.Write(r.Body)
+	buf.WriteString("\r\n\r\n")
+	return buf.Bytes()
+}
+
+func writeHeader(buf *bytes.Buffer, key, value string) {
+	buf.WriteString(key)
+	buf.WriteString(": ")
+	buf.WriteString(value)
+	buf.WriteString("\r\n")
+}
+
+// deterministicRecordID derives a stable WARC-Record-ID (formatted as a
+// urn:uuid) from a record's type, target and timestamp, so the same three
+// values always produce the same ID.
+func deterministicRecordID(t RecordType, targetURI string, date time.Time) string {
+	h := sha1.New()
+	io.WriteString(h, string(t))
+	io.WriteString(h, targetURI)
+	io.WriteString(h, date.UTC().Format(time.RFC3339Nano))
+	sum := h.Sum(nil)
+	return fmt.Sprintf("urn:uuid:%x-%x-%x-%x-%x", sum[0:4], sum[4:6], sum[6:8], sum[8:10], sum[10:16])
+}
+
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
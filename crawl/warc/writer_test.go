@@ -0,0 +1,84 @@
+package warc
+
+import (
+	"compress/gzip"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Writer_WriteRecord(t *testing.T) {
+	asserter := assert.New(t)
+
+	dir, err := ioutil.TempDir("", "warc_test")
+	asserter.NoError(err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "crawl.warc.gz")
+	w, err := NewWriter(path, 0)
+	asserter.NoError(err)
+
+	date := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	id, err := w.WriteRecord(Record{
+		Type:        RecordTypeResponse,
+		Date:        date,
+		TargetURI:   "http://foo.bar.com/",
+		ContentType: "application/http;msgtype=response",
+		Body:        []byte("HTTP/1.1 200 OK\r\n\r\nhello"),
+	})
+	asserter.NoError(err)
+	asserter.NotEmpty(id)
+	asserter.NoError(w.Close())
+
+	f, err := os.Open(path)
+	asserter.NoError(err)
+	defer f.Close()
+
+	gz, err := gzip.NewReader(f)
+	asserter.NoError(err)
+	contents, err := ioutil.ReadAll(gz)
+	asserter.NoError(err)
+
+	asserter.Contains(string(contents), "WARC/1.1\r\n")
+	asserter.Contains(string(contents), "WARC-Type: response\r\n")
+	asserter.Contains(string(contents), "WARC-Target-URI: http://foo.bar.com/\r\n")
+	asserter.Contains(string(contents), "hello")
+}
+
+func Test_Writer_RecordIDsAreDeterministic(t *testing.T) {
+	asserter := assert.New(t)
+
+	date := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	first := deterministicRecordID(RecordTypeResponse, "http://foo.bar.com/", date)
+	second := deterministicRecordID(RecordTypeResponse, "http://foo.bar.com/", date)
+	asserter.Equal(first, second)
+
+	different := deterministicRecordID(RecordTypeResponse, "http://foo.bar.com/other", date)
+	asserter.NotEqual(first, different)
+}
+
+func Test_Writer_RotatesOnSize(t *testing.T) {
+	asserter := assert.New(t)
+
+	dir, err := ioutil.TempDir("", "warc_test")
+	asserter.NoError(err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "crawl.warc.gz")
+	w, err := NewWriter(path, 1)
+	asserter.NoError(err)
+
+	date := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	_, err = w.WriteRecord(Record{Type: RecordTypeResponse, Date: date, TargetURI: "http://foo.bar.com/a", Body: []byte("a")})
+	asserter.NoError(err)
+	_, err = w.WriteRecord(Record{Type: RecordTypeResponse, Date: date, TargetURI: "http://foo.bar.com/b", Body: []byte("b")})
+	asserter.NoError(err)
+	asserter.NoError(w.Close())
+
+	asserter.FileExists(path)
+	asserter.FileExists(filepath.Join(dir, "crawl-00001.warc.gz"))
+}
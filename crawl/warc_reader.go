@@ -0,0 +1,93 @@
+package crawl
+
+import (
+	"context"
+	"net/http"
+	"net/http/httputil"
+	"sync"
+	"time"
+
+	"github.com/jonsabados/crawler/crawl/warc"
+	"github.com/rs/zerolog"
+)
+
+type warcContextKey struct{}
+
+func withWARCRecorder(ctx context.Context, r *warcRecorder) context.Context {
+	return context.WithValue(ctx, warcContextKey{}, r)
+}
+
+func warcRecorderFromContext(ctx context.Context) (*warcRecorder, bool) {
+	r, ok := ctx.Value(warcContextKey{}).(*warcRecorder)
+	return r, ok
+}
+
+// warcRecorder archives the HTTP request/response pairs ReadDocument sees
+// for a single crawl as WARC 1.1 records.
+type warcRecorder struct {
+	writer       *warc.Writer
+	warcinfoOnce sync.Once
+}
+
+func (r *warcRecorder) record(req *http.Request, res *http.Response) {
+	r.ensureWarcinfo()
+
+	reqBytes, err := httputil.DumpRequestOut(req, true)
+	if err != nil {
+		zerolog.Ctx(req.Context()).Warn().Err(err).Msg("unable to dump request for WARC archiving")
+		return
+	}
+	resBytes, err := httputil.DumpResponse(res, true)
+	if err != nil {
+		zerolog.Ctx(req.Context()).Warn().Err(err).Msg("unable to dump response for WARC archiving")
+		return
+	}
+
+	now := time.Now().UTC()
+	requestID, err := r.writer.WriteRecord(warc.Record{
+		Type:        warc.RecordTypeRequest,
+		Date:        now,
+		TargetURI:   req.URL.String(),
+		ContentType: "application/http;msgtype=request",
+		Body:        reqBytes,
+	})
+	if err != nil {
+		zerolog.Ctx(req.Context()).Warn().Err(err).Msg("unable to write WARC request record")
+		return
+	}
+
+	_, err = r.writer.WriteRecord(warc.Record{
+		Type:         warc.RecordTypeResponse,
+		Date:         now,
+		TargetURI:    req.URL.String(),
+		ContentType:  "application/http;msgtype=response",
+		ConcurrentTo: requestID,
+		Body:         resBytes,
+	})
+	if err != nil {
+		zerolog.Ctx(req.Context()).Warn().Err(err).Msg("unable to write WARC response record")
+	}
+}
+
+func (r *warcRecorder) ensureWarcinfo() {
+	r.warcinfoOnce.Do(func() {
+		body := []byte("software: crawler\r\nformat: WARC File Format 1.1\r\n")
+		_, _ = r.writer.WriteRecord(warc.Record{
+			Type:        warc.RecordTypeWarcinfo,
+			Date:        time.Now().UTC(),
+			ContentType: "application/warc-fields",
+			Body:        body,
+		})
+	})
+}
+
+// NewWARCRecordingReader decorates baseReader so that every HTTP
+// request/response pair ReadDocument performs on its behalf is additionally
+// archived as WARC 1.1 records written to writer, turning the crawler into
+// an archival tool rather than one that only produces an in-memory site map.
+func NewWARCRecordingReader(baseReader DocumentReader, writer *warc.Writer) DocumentReader {
+	recorder := &warcRecorder{writer: writer}
+	return func(ctx context.Context, url string) ([]Link, error) {
+		return baseReader(withWARCRecorder(ctx, recorder), url)
+	}
+}
@@ -0,0 +1,53 @@
+package crawl
+
+import (
+	"compress/gzip"
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/jonsabados/crawler/crawl/warc"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_NewWARCRecordingReader(t *testing.T) {
+	asserter := assert.New(t)
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := ioutil.ReadFile("testresources/valid.html")
+		asserter.NoError(err)
+		w.Write(body)
+	}))
+	defer ts.Close()
+
+	dir, err := ioutil.TempDir("", "warc_reader_test")
+	asserter.NoError(err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "crawl.warc.gz")
+	writer, err := warc.NewWriter(path, 0)
+	asserter.NoError(err)
+
+	reader := NewWARCRecordingReader(ReadDocument, writer)
+
+	links, err := reader(context.Background(), ts.URL+"/foo/bar.html")
+	asserter.NoError(err)
+	asserter.NotEmpty(links)
+	asserter.NoError(writer.Close())
+
+	f, err := os.Open(path)
+	asserter.NoError(err)
+	defer f.Close()
+
+	gz, err := gzip.NewReader(f)
+	asserter.NoError(err)
+	contents, err := ioutil.ReadAll(gz)
+	asserter.NoError(err)
+
+	asserter.Equal(3, strings.Count(string(contents), "WARC-Type: ")) // warcinfo, request, response
+}
@@ -32,7 +32,10 @@ func (p *idleWorkerTracker) IdleStats(workerNo int) (time.Time, bool) {
 // Await returns a channel that can be listened to to for worker completion, and another channel that can be used to stop workers
 func (p *idleWorkerTracker) Await(workerCount int, idleThreshold time.Duration) (<-chan bool,  chan<- bool) {
 	complete := make(chan bool)
-	cancel := make(chan bool)
+	// cancel is buffered so a caller that stops watching after complete has
+	// already fired (the monitoring goroutine below will have exited by
+	// then) doesn't block forever sending to it.
+	cancel := make(chan bool, 1)
 	go func() {
 		ticker := time.NewTicker(time.Millisecond * 10)
 		defer ticker.Stop()
@@ -68,32 +71,41 @@ func newIdleWorkerTracker() idleWorkerTracker {
 	}
 }
 
+// frontierEntry is a single URL queued for a worker to fetch. recurse
+// mirrors the LinkScope the URL was discovered under - primary navigational
+// links recurse into their own extracted links, while related sub-resources
+// (images, scripts, stylesheets) are fetched and recorded but never expanded.
+type frontierEntry struct {
+	url     string
+	recurse bool
+}
+
+// urlTracker guards the frontier channel against duplicate work, consulting
+// a CrawlStore rather than keeping its own seen-set so dedupe state survives
+// across a resumed crawl.
 type urlTracker struct {
-	sync.Mutex
-	urlsSeen   map[string]bool
-	urlsToWork chan string
+	store      CrawlStore
+	urlsToWork chan frontierEntry
 }
 
-func (v *urlTracker) appendURL(url string) {
-	v.Lock()
-	_, seen := v.urlsSeen[url]
-	if !seen {
+func (v *urlTracker) appendURL(url string, recurse bool) error {
+	alreadySeen, err := v.store.MarkSeen(url, recurse)
+	if err != nil {
+		return err
+	}
+	if !alreadySeen {
 		// if workers aren't keeping up with the number of links we have seen things will deadlock
-		// so just do that in a separate goroutine. l can also be changed so it needs to be
-		// an argument to the func were invoking rather than just doing urlsToProcess <- l
+		// so just do that in a separate goroutine.
 		go func() {
-			v.urlsToWork <- url
+			v.urlsToWork <- frontierEntry{url, recurse}
 		}()
-		v.urlsSeen[url] = true
 	}
-	v.Unlock()
+	return nil
 }
 
-func newURLTracker(startingURL string, urlsToWork chan string) urlTracker {
+func newURLTracker(store CrawlStore, urlsToWork chan frontierEntry) urlTracker {
 	return urlTracker{
-		urlsSeen: map[string]bool{
-			startingURL: true,
-		},
+		store:      store,
 		urlsToWork: urlsToWork,
 	}
 }
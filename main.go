@@ -4,17 +4,34 @@ import (
 	"flag"
 	"fmt"
 	"github.com/jonsabados/crawler/crawl"
+	"github.com/jonsabados/crawler/crawl/report"
+	"github.com/jonsabados/crawler/crawl/store"
+	"github.com/jonsabados/crawler/crawl/warc"
 	"github.com/rs/zerolog"
 	"net/url"
 	"os"
+	"path/filepath"
 	"time"
 )
 
+// maxWARCFileSize is the size, in bytes, a .warc.gz file is allowed to reach
+// before the crawler rotates to a new one.
+const maxWARCFileSize = 1 << 30 // 1GiB
+
 func main() {
 	startingURL := flag.String("url", "", "required - starting point for crawl and must be an http or https url. Only links on the same domain will be searched")
 	workerCount := flag.Int("workers", 10, "how many workers to execute with")
 	readTimeout := flag.Int("readTimeout", 500, "http read timeout in milliseconds (per URL seen)")
+	idleThreshold := flag.Int("idleThreshold", 200, "how long, in milliseconds, every worker must sit idle before the crawl is considered finished")
 	executionTimeout := flag.Int("executionTimeout", 120, "total execution timout, in seconds")
+	userAgent := flag.String("userAgent", "crawler/1.0 (+https://github.com/jonsabados/crawler)", "user-agent string to present when fetching robots.txt and pages")
+	defaultDelay := flag.Int("defaultDelay", 0, "default per-host delay, in milliseconds, to apply between requests when a host's robots.txt does not specify a Crawl-delay")
+	ignoreRobots := flag.Bool("ignoreRobots", false, "if set robots.txt is not fetched or honored, and no per-host pacing is applied")
+	output := flag.String("output", "", "if set, archive every page fetched as WARC 1.1 records to this file (e.g. crawl.warc.gz), turning the crawler into an archival tool")
+	state := flag.String("state", "", "if set, persist crawl state (seen URLs, pending frontier, per-URL results) to this directory so an interrupted crawl can be continued with -resume")
+	resume := flag.Bool("resume", false, "continue the crawl persisted under -state rather than starting fresh; requires -state")
+	format := flag.String("format", "text", "result output format: text, json, graphml, or sitemap")
+	out := flag.String("out", "", "if set, write the rendered result to this file instead of stdout")
 
 	flag.Parse()
 
@@ -23,6 +40,17 @@ func main() {
 		os.Exit(1)
 	}
 
+	var renderer report.Renderer
+	if *format != "text" {
+		var ok bool
+		renderer, ok = report.Renderers[*format]
+		if !ok {
+			fmt.Fprintf(os.Stderr, "unknown -format %q\n", *format)
+			flag.Usage()
+			os.Exit(6)
+		}
+	}
+
 	u, err := url.Parse(*startingURL)
 	if err != nil {
 		flag.Usage()
@@ -34,23 +62,95 @@ func main() {
 		os.Exit(3)
 	}
 
+	if *resume && *state == "" {
+		fmt.Fprintln(os.Stderr, "-resume requires -state")
+		flag.Usage()
+		os.Exit(4)
+	}
+
 	logger := zerolog.New(os.Stdout).Level(zerolog.InfoLevel)
-	crawler, stop := crawl.NewCrawler(logger, *workerCount, time.Duration(*readTimeout) * time.Millisecond, crawl.ReadDocument, crawl.SameDomainEligibilityChecker(*startingURL))
+
+	var robots *crawl.RobotsPolicy
+	if !*ignoreRobots {
+		robots = crawl.NewRobotsPolicy(*userAgent, time.Duration(*defaultDelay)*time.Millisecond)
+	}
+
+	var warcWriter *warc.Writer
+	reader := crawl.ReadDocument
+	if *output != "" {
+		warcWriter, err = warc.NewWriter(*output, maxWARCFileSize)
+		if err != nil {
+			panic(err)
+		}
+		reader = crawl.NewWARCRecordingReader(reader, warcWriter)
+	}
+
+	var crawlStore crawl.CrawlStore
+	var boltStore *store.BoltCrawlStore
+	if *state != "" {
+		if err := os.MkdirAll(*state, 0755); err != nil {
+			panic(err)
+		}
+		dbPath := filepath.Join(*state, "crawl.db")
+		if _, statErr := os.Stat(dbPath); statErr == nil && !*resume {
+			fmt.Fprintf(os.Stderr, "state already exists at %s - pass -resume to continue that crawl or remove it to start fresh\n", dbPath)
+			os.Exit(5)
+		}
+		boltStore, err = store.NewBoltCrawlStore(dbPath)
+		if err != nil {
+			panic(err)
+		}
+		crawlStore = boltStore
+	}
+
+	crawler, stop := crawl.NewCrawler(logger, *workerCount, time.Duration(*readTimeout) * time.Millisecond, time.Duration(*idleThreshold) * time.Millisecond, reader, crawl.SameDomainEligibilityChecker(*startingURL), robots, crawlStore)
 
 	go func() {
 		time.Sleep(time.Duration(*executionTimeout) * time.Second)
-		stop()
+		stop <- true
 	}()
 
 	res, err := crawler(*startingURL)
 	if err != nil {
 		panic(err)
 	}
-	for u, links := range res {
-		fmt.Fprintf(os.Stdout, "Links for %s\n", u)
-		for _, l := range links {
-			fmt.Fprintf(os.Stdout, "\t%s: %s\n", l.LinkType, l.LinkTarget)
+
+	dest := os.Stdout
+	if *out != "" {
+		dest, err = os.Create(*out)
+		if err != nil {
+			panic(err)
+		}
+	}
+
+	if renderer != nil {
+		if err := renderer(dest, res); err != nil {
+			panic(err)
+		}
+	} else {
+		for u, links := range res {
+			fmt.Fprintf(dest, "Links for %s\n", u)
+			for _, l := range links {
+				fmt.Fprintf(dest, "\t%s (%s): %s\n", l.LinkType, l.Tag, l.LinkTarget)
+			}
 		}
 	}
+
+	if warcWriter != nil {
+		if err := warcWriter.Close(); err != nil {
+			panic(err)
+		}
+	}
+	if boltStore != nil {
+		if err := boltStore.Close(); err != nil {
+			panic(err)
+		}
+	}
+	if *out != "" {
+		if err := dest.Close(); err != nil {
+			panic(err)
+		}
+	}
+
 	os.Exit(0)
 }